@@ -30,14 +30,127 @@ type ApplicationCloneSpec struct {
 	Selectors    map[string]string `json:"selectors"`
 	PreExecRule  string            `json:"preExecRule"`
 	PostExecRule string            `json:"postExecRule"`
+	//FailurePolicy controls what happens when cloning a single object fails
+	FailurePolicy ApplicationCloneFailurePolicyType `json:"failurePolicy,omitempty"`
+	//SnapshotSpec configures the intermediate CSI VolumeSnapshot stage used
+	//to take a consistent point-in-time clone of the source PVCs before
+	//provisioning the target PVCs from it
+	SnapshotSpec ApplicationCloneSnapshotSpec `json:"snapshotSpec,omitempty"`
+	//IncludeResources restricts the clone to only these resources, in
+	//addition to the Selectors match. If empty, all resources matching
+	//Selectors are cloned
+	IncludeResources []ResourceInfo `json:"includeResources,omitempty"`
+	//ExcludeResources removes these resources from the clone, even if they
+	//match Selectors or IncludeResources
+	ExcludeResources []ResourceInfo `json:"excludeResources,omitempty"`
+	//Transformations are applied, in order, to every cloned resource's
+	//manifest after it's rendered and before it's created on the
+	//destination cluster, e.g. to swap image registries, storage classes,
+	//ingress hostnames or replica counts between environments
+	Transformations []ResourceTransformationSpec `json:"transformations,omitempty"`
+	//SchedulePolicyName is the name of the SchedulePolicy (interval, daily,
+	//weekly or monthly) used to trigger this clone when it's run from an
+	//ApplicationCloneSchedule. It's ignored for one-shot ApplicationClones
+	SchedulePolicyName string `json:"schedulePolicyName,omitempty"`
 }
 
+//ResourceInfo identifies a single resource by kind and name, used to
+//include or exclude specific objects from an ApplicationClone
+type ResourceInfo struct {
+	//Group is the API group of the resource
+	Group string `json:"group,omitempty"`
+	//Version is the API version of the resource
+	Version string `json:"version,omitempty"`
+	//Kind is the kind of the resource
+	Kind string `json:"kind"`
+	//Namespace is the source namespace of the resource
+	Namespace string `json:"namespace,omitempty"`
+	//Name is the name of the resource
+	Name string `json:"name"`
+}
+
+//ApplicationCloneSnapshotSpec configures the snapshot-then-clone workflow
+type ApplicationCloneSnapshotSpec struct {
+	//Enabled turns on the snapshot-then-clone workflow. When false, PVCs are
+	//cloned live as before
+	Enabled bool `json:"enabled"`
+	//SnapshotClassName is the CSI VolumeSnapshotClass used to create the
+	//intermediate VolumeSnapshot of each source PVC
+	SnapshotClassName string `json:"snapshotClassName,omitempty"`
+	//RetainSnapshot keeps the intermediate VolumeSnapshot around after the
+	//target PVCs have been provisioned from it, instead of deleting it
+	RetainSnapshot bool `json:"retainSnapshot,omitempty"`
+	//TargetGroup is the VolumeSnapshot group/label used to provision all
+	//target PVCs of a multi-PVC application from the same point-in-time
+	//snapshot set
+	TargetGroup string `json:"targetGroup,omitempty"`
+}
+
+//ApplicationCloneFailurePolicyType defines how a per-object failure affects the rest of the clone
+type ApplicationCloneFailurePolicyType string
+
+const (
+	//ApplicationCloneFailurePolicyAbort aborts the whole clone on the first object failure
+	ApplicationCloneFailurePolicyAbort ApplicationCloneFailurePolicyType = "Abort"
+	//ApplicationCloneFailurePolicyContinue records the failure on the object and continues cloning the rest
+	ApplicationCloneFailurePolicyContinue ApplicationCloneFailurePolicyType = "Continue"
+)
+
 //ApplicationCloneStatus defines the status of the clone
 type ApplicationCloneStatus struct {
 	//Status of the cloning process
 	Status ApplicationCloneStatusType `json:"status"`
 	//Stage of the cloning process
 	Stage ApplicationCloneStageType `json:"stage"`
+	//Conditions are the per-phase status conditions of the clone, following
+	//the Kubernetes condition convention
+	Conditions []ApplicationCloneCondition `json:"conditions,omitempty"`
+	//Resources reports the per-object cloning status
+	Resources []ApplicationCloneResourceInfo `json:"resources,omitempty"`
+}
+
+//ApplicationCloneCondition is a Kubernetes-style condition reporting the status of one phase of the clone
+type ApplicationCloneCondition struct {
+	//Type of the condition
+	Type ApplicationCloneConditionType `json:"type"`
+	//Status of the condition
+	Status meta.ConditionStatus `json:"status"`
+	//LastTransitionTime is the last time the condition transitioned from one status to another
+	LastTransitionTime meta.Time `json:"lastTransitionTime,omitempty"`
+	//Reason is a brief machine-readable explanation for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	//Message is a human-readable explanation of the condition's last transition
+	Message string `json:"message,omitempty"`
+}
+
+//ApplicationCloneConditionType is the type of an ApplicationCloneCondition
+type ApplicationCloneConditionType string
+
+const (
+	//ApplicationCloneConditionVolumesCloned is true once all volumes have been cloned
+	ApplicationCloneConditionVolumesCloned ApplicationCloneConditionType = "VolumesCloned"
+	//ApplicationCloneConditionResourcesApplied is true once all resources have been applied to the destination
+	ApplicationCloneConditionResourcesApplied ApplicationCloneConditionType = "ResourcesApplied"
+	//ApplicationCloneConditionPreExecRuleCompleted is true once the pre-exec rule has completed
+	ApplicationCloneConditionPreExecRuleCompleted ApplicationCloneConditionType = "PreExecRuleCompleted"
+	//ApplicationCloneConditionPostExecRuleCompleted is true once the post-exec rule has completed
+	ApplicationCloneConditionPostExecRuleCompleted ApplicationCloneConditionType = "PostExecRuleCompleted"
+	//ApplicationCloneConditionReplicaFailure is true if one or more objects failed to clone
+	ApplicationCloneConditionReplicaFailure ApplicationCloneConditionType = "ReplicaFailure"
+)
+
+//ApplicationCloneResourceInfo reports the cloning status of a single object
+type ApplicationCloneResourceInfo struct {
+	//Kind of the object
+	Kind string `json:"kind"`
+	//Name of the object
+	Name string `json:"name"`
+	//Namespace of the object
+	Namespace string `json:"namespace"`
+	//Status of cloning this object
+	Status ApplicationCloneStatusType `json:"status"`
+	//Reason is a human-readable explanation when Status is Failed
+	Reason string `json:"reason,omitempty"`
 }
 
 //ApplicationCloneStatusType defines status of the application being cloned
@@ -56,6 +169,10 @@ const (
 	ApplicationCloneStatusSuccess ApplicationCloneStatusType = "Success"
 	//ApplicationCloneStatusPartialSuccess when cloning was only partially successful
 	ApplicationCloneStatusPartialSuccess ApplicationCloneStatusType = "PartialSuccess"
+	//ApplicationCloneStatusSnapshotPending when the intermediate VolumeSnapshot has been requested but isn't ReadyToUse yet
+	ApplicationCloneStatusSnapshotPending ApplicationCloneStatusType = "SnapshotPending"
+	//ApplicationCloneStatusSnapshotInProgress when target PVCs are being provisioned from the intermediate VolumeSnapshot
+	ApplicationCloneStatusSnapshotInProgress ApplicationCloneStatusType = "SnapshotInProgress"
 )
 
 //ApplicationCloneStageType defines the stage of the cloning process
@@ -68,6 +185,9 @@ const (
 	ApplicationCloneStagePreExecRule ApplicationCloneStageType = "PreExecRule"
 	//ApplicationCloneStagePostExecRule stage when post-exec rules are being executed
 	ApplicationCloneStagePostExecRule ApplicationCloneStageType = "PostExecRule"
+	//ApplicationCloneStageSnapshot stage where the intermediate VolumeSnapshot
+	//of the source PVCs is taken, when SnapshotSpec.Enabled is set
+	ApplicationCloneStageSnapshot ApplicationCloneStageType = "Snapshot"
 	//ApplicationCloneStageVolumeClone stage where the volumes are being cloned
 	ApplicationCloneStageVolumeClone ApplicationCloneStageType = "VolumeClone"
 	//ApplicationCloneStageApplicationClone stage when applications are being cloned
@@ -0,0 +1,92 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	//ApplicationCloneScheduleResourceName is the name for the application clone schedule resource
+	ApplicationCloneScheduleResourceName = "applicationcloneschedule"
+	//ApplicationCloneScheduleResourcePlural is the name in plural for the application clone schedule resources
+	ApplicationCloneScheduleResourcePlural = "applicationcloneschedules"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//ApplicationCloneSchedule represents a scheduled recurring ApplicationClone,
+//such as a nightly prod-to-dev refresh
+type ApplicationCloneSchedule struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            ApplicationCloneScheduleSpec   `json:"spec"`
+	Status          ApplicationCloneScheduleStatus `json:"status,omitempty"`
+}
+
+//ApplicationCloneScheduleSpec defines the spec to create an application clone schedule
+type ApplicationCloneScheduleSpec struct {
+	//Template is the spec used to create each child ApplicationClone on a
+	//schedule tick. Its SchedulePolicyName is ignored; SchedulePolicyName
+	//below is what selects the trigger interval
+	Template ApplicationCloneSpec `json:"template"`
+	//SchedulePolicyName is the name of the SchedulePolicy (interval, daily,
+	//weekly or monthly) that controls how often child clones are created
+	SchedulePolicyName string `json:"schedulePolicyName"`
+	//Suspend pauses the creation of new child ApplicationClones. Existing
+	//children and their retained history are left untouched
+	Suspend bool `json:"suspend,omitempty"`
+	//Retain is the number of most recent child ApplicationClones to keep;
+	//older ones are garbage-collected as new ones are created
+	Retain int `json:"retain,omitempty"`
+}
+
+//ApplicationCloneScheduleStatus defines the status of the application clone schedule
+type ApplicationCloneScheduleStatus struct {
+	//Items is the list of child ApplicationClones created by this
+	//schedule, most recent first, up to Retain entries
+	Items []ApplicationCloneScheduleStatusItem `json:"items,omitempty"`
+	//Conditions are the status conditions of the schedule itself, such as
+	//Suspended
+	Conditions []ApplicationCloneScheduleCondition `json:"conditions,omitempty"`
+}
+
+//ApplicationCloneScheduleStatusItem tracks one child ApplicationClone created by a schedule
+type ApplicationCloneScheduleStatusItem struct {
+	//Name of the child ApplicationClone
+	Name string `json:"name"`
+	//CreationTimestamp of the child ApplicationClone
+	CreationTimestamp meta.Time `json:"creationTimestamp,omitempty"`
+	//Status of the child ApplicationClone
+	Status ApplicationCloneStatusType `json:"status"`
+}
+
+//ApplicationCloneScheduleCondition is a Kubernetes-style condition reporting the status of the schedule
+type ApplicationCloneScheduleCondition struct {
+	//Type of the condition
+	Type ApplicationCloneScheduleConditionType `json:"type"`
+	//Status of the condition
+	Status meta.ConditionStatus `json:"status"`
+	//LastTransitionTime is the last time the condition transitioned from one status to another
+	LastTransitionTime meta.Time `json:"lastTransitionTime,omitempty"`
+	//Reason is a brief machine-readable explanation for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	//Message is a human-readable explanation of the condition's last transition
+	Message string `json:"message,omitempty"`
+}
+
+//ApplicationCloneScheduleConditionType is the type of an ApplicationCloneScheduleCondition
+type ApplicationCloneScheduleConditionType string
+
+const (
+	//ApplicationCloneScheduleConditionSuspended is true while the schedule is paused and not creating new child clones
+	ApplicationCloneScheduleConditionSuspended ApplicationCloneScheduleConditionType = "Suspended"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ApplicationCloneScheduleList is a list of ApplicationCloneSchedules
+type ApplicationCloneScheduleList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+	Items         []ApplicationCloneSchedule `json:"items"`
+}
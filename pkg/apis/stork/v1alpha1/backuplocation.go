@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -60,20 +61,146 @@ type S3Config struct {
 	SecretAccessKey string `json:"secretAccessKey"`
 	// Region will be defaulted to us-east-1 by the controller if not provided
 	Region string `json:"region"`
+	// AuthMode selects how the driver authenticates against the
+	// objectstore. When empty, UpadteFromSecret sets it to S3AuthModeIRSA if
+	// both RoleARN and WebIdentityTokenFile are set, and to S3AuthModeStatic
+	// otherwise.
+	AuthMode S3AuthModeType `json:"authMode"`
+	// RoleARN is the IAM role to assume via IRSA, only used with AuthMode
+	// S3AuthModeIRSA
+	RoleARN string `json:"roleARN"`
+	// WebIdentityTokenFile is the path to the projected service account
+	// token used to assume RoleARN, only used with AuthMode S3AuthModeIRSA
+	WebIdentityTokenFile string `json:"webIdentityTokenFile"`
 }
 
+// S3AuthModeType is the mode used to authenticate with an S3-compliant
+// objectstore
+type S3AuthModeType string
+
+const (
+	// S3AuthModeStatic authenticates using a static AccessKeyID/SecretAccessKey pair
+	S3AuthModeStatic S3AuthModeType = "static-credentials"
+	// S3AuthModeIRSA authenticates by assuming RoleARN via IAM Roles for
+	// Service Accounts, exchanging the pod's projected service account
+	// token for temporary AWS credentials
+	S3AuthModeIRSA S3AuthModeType = "irsa"
+)
+
+// Environment variables populated by the EKS Pod Identity webhook when a
+// pod's service account is annotated with an IAM role to assume
+const (
+	awsRoleARNEnvVar              = "AWS_ROLE_ARN"
+	awsWebIdentityTokenFileEnvVar = "AWS_WEB_IDENTITY_TOKEN_FILE"
+)
+
 // AzureConfig specifies the config required to connect to Azure Blob Storage
 type AzureConfig struct {
 	StorageAccountName string `json:"storageAccountName"`
 	StorageAccountKey  string `json:"storageAccountKey"`
+	// AuthMode selects how the driver authenticates against the storage
+	// account. When empty, UpadteFromSecret infers it from the populated
+	// fields: workload-identity, then service-principal, then shared-key,
+	// falling back to managed-identity.
+	AuthMode AzureAuthModeType `json:"authMode"`
+	// TenantID is the Azure AD tenant used for service-principal or
+	// workload-identity authentication
+	TenantID string `json:"tenantID"`
+	// ClientID is the Azure AD application (client) ID used for
+	// service-principal or workload-identity authentication
+	ClientID string `json:"clientID"`
+	// ClientSecret is the Azure AD application secret, only used with
+	// AuthMode AzureAuthModeServicePrincipal
+	ClientSecret string `json:"clientSecret"`
+	// FederatedTokenFile is the path to the projected service account
+	// token used to authenticate with AuthMode AzureAuthModeWorkloadIdentity
+	FederatedTokenFile string `json:"federatedTokenFile"`
+	// Environment is the Azure cloud the storage account lives in.
+	// Defaults to AzurePublicCloud when empty.
+	Environment AzureEnvironmentType `json:"environment"`
 }
 
+// AzureEnvironmentType is the Azure cloud a storage account belongs to
+type AzureEnvironmentType string
+
+const (
+	// AzurePublicCloud is the default, public Azure cloud
+	AzurePublicCloud AzureEnvironmentType = "AzurePublicCloud"
+	// AzureUSGovernmentCloud is the Azure US Government sovereign cloud
+	AzureUSGovernmentCloud AzureEnvironmentType = "AzureUSGovernmentCloud"
+	// AzureChinaCloud is the Azure China sovereign cloud, operated by 21Vianet
+	AzureChinaCloud AzureEnvironmentType = "AzureChinaCloud"
+	// AzureGermanCloud is the Azure Germany sovereign cloud
+	AzureGermanCloud AzureEnvironmentType = "AzureGermanCloud"
+)
+
+// azureStorageEndpointSuffixes maps each supported Azure cloud to the
+// storage endpoint suffix used to build Blob Storage URLs in that cloud
+var azureStorageEndpointSuffixes = map[AzureEnvironmentType]string{
+	AzurePublicCloud:       "core.windows.net",
+	AzureUSGovernmentCloud: "core.usgovcloudapi.net",
+	AzureChinaCloud:        "core.chinacloudapi.cn",
+	AzureGermanCloud:       "core.cloudapi.de",
+}
+
+// StorageEndpointSuffix returns the storage endpoint suffix for the
+// AzureConfig's Environment, so that volume drivers can target sovereign
+// regions without hard-coding endpoints. Defaults to AzurePublicCloud's
+// suffix if Environment is unset or unrecognized.
+func (a *AzureConfig) StorageEndpointSuffix() string {
+	if suffix, ok := azureStorageEndpointSuffixes[a.Environment]; ok {
+		return suffix
+	}
+	return azureStorageEndpointSuffixes[AzurePublicCloud]
+}
+
+// AzureAuthModeType is the mode used to authenticate with Azure Blob Storage
+type AzureAuthModeType string
+
+const (
+	// AzureAuthModeSharedKey authenticates using the storage account name
+	// and key
+	AzureAuthModeSharedKey AzureAuthModeType = "shared-key"
+	// AzureAuthModeServicePrincipal authenticates using an Azure AD
+	// application's tenant ID, client ID and client secret
+	AzureAuthModeServicePrincipal AzureAuthModeType = "service-principal"
+	// AzureAuthModeWorkloadIdentity authenticates by exchanging a
+	// projected Kubernetes service account token for an Azure AD token,
+	// as used by AKS workload identity
+	AzureAuthModeWorkloadIdentity AzureAuthModeType = "workload-identity"
+	// AzureAuthModeManagedIdentity authenticates using the managed
+	// identity assigned to the node/pod
+	AzureAuthModeManagedIdentity AzureAuthModeType = "managed-identity"
+)
+
+// Environment variables populated by AKS pod-identity/workload-identity
+// webhooks when a pod is bound to an Azure AD federated identity
+const (
+	azureTenantIDEnvVar           = "AZURE_TENANT_ID"
+	azureClientIDEnvVar           = "AZURE_CLIENT_ID"
+	azureFederatedTokenFileEnvVar = "AZURE_FEDERATED_TOKEN_FILE"
+)
+
 // GoogleConfig specifies the config required to connect to Google Cloud Storage
 type GoogleConfig struct {
 	ProjectID  string `json:"projectID"`
 	AccountKey string `json:"accountKey"`
+	// AuthMode selects how the driver authenticates against the bucket.
+	// Defaults to GoogleAuthModeStatic when empty.
+	AuthMode GoogleAuthModeType `json:"authMode"`
 }
 
+// GoogleAuthModeType is the mode used to authenticate with Google Cloud Storage
+type GoogleAuthModeType string
+
+const (
+	// GoogleAuthModeStatic authenticates using a static service account AccountKey
+	GoogleAuthModeStatic GoogleAuthModeType = "static-credentials"
+	// GoogleAuthModeWorkloadIdentity authenticates using GKE Workload
+	// Identity, resolving credentials from the GCE metadata server
+	GoogleAuthModeWorkloadIdentity GoogleAuthModeType = "workload-identity"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // BackupLocationList is a list of ApplicationBackups
@@ -84,11 +211,11 @@ type BackupLocationList struct {
 	Items []BackupLocation `json:"items"`
 }
 
-// UpadteFromSecret updated the config information from the secret if not provided inline
+// UpadteFromSecret updated the config information from the secret if not provided inline.
+// The per-type merge also runs when SecretConfig is empty, since that's the
+// expected configuration for IRSA/workload-identity setups where there are
+// no static credentials to store in a secret at all.
 func (bl *BackupLocation) UpadteFromSecret(client kubernetes.Interface) error {
-	if bl.Location.SecretConfig == "" {
-		return nil
-	}
 	switch bl.Location.Type {
 	case BackupLocationS3:
 		return bl.getMergedS3Config(client)
@@ -124,7 +251,31 @@ func (bl *BackupLocation) getMergedS3Config(client kubernetes.Interface) error {
 		if _, ok := secretConfig.Data["region"]; ok {
 			bl.Location.S3Config.SecretAccessKey = strings.TrimSuffix(string(secretConfig.Data["region"]), "\n")
 		}
+		if _, ok := secretConfig.Data["roleARN"]; ok {
+			bl.Location.S3Config.RoleARN = strings.TrimSuffix(string(secretConfig.Data["roleARN"]), "\n")
+		}
+		if _, ok := secretConfig.Data["webIdentityTokenFile"]; ok {
+			bl.Location.S3Config.WebIdentityTokenFile = strings.TrimSuffix(string(secretConfig.Data["webIdentityTokenFile"]), "\n")
+		}
+	}
+
+	// Fall back to the pod-projected IRSA env vars when the secret didn't
+	// provide explicit credentials
+	if bl.Location.S3Config.RoleARN == "" {
+		bl.Location.S3Config.RoleARN = os.Getenv(awsRoleARNEnvVar)
+	}
+	if bl.Location.S3Config.WebIdentityTokenFile == "" {
+		bl.Location.S3Config.WebIdentityTokenFile = os.Getenv(awsWebIdentityTokenFileEnvVar)
+	}
+
+	if bl.Location.S3Config.AuthMode == "" {
+		if bl.Location.S3Config.RoleARN != "" && bl.Location.S3Config.WebIdentityTokenFile != "" {
+			bl.Location.S3Config.AuthMode = S3AuthModeIRSA
+		} else {
+			bl.Location.S3Config.AuthMode = S3AuthModeStatic
+		}
 	}
+
 	return nil
 }
 
@@ -143,10 +294,60 @@ func (bl *BackupLocation) getMergedAzureConfig(client kubernetes.Interface) erro
 		if _, ok := secretConfig.Data["storageAccountKey"]; ok {
 			bl.Location.AzureConfig.StorageAccountKey = strings.TrimSuffix(string(secretConfig.Data["storageAccountKey"]), "\n")
 		}
+		if _, ok := secretConfig.Data["tenantID"]; ok {
+			bl.Location.AzureConfig.TenantID = strings.TrimSuffix(string(secretConfig.Data["tenantID"]), "\n")
+		}
+		if _, ok := secretConfig.Data["clientID"]; ok {
+			bl.Location.AzureConfig.ClientID = strings.TrimSuffix(string(secretConfig.Data["clientID"]), "\n")
+		}
+		if _, ok := secretConfig.Data["clientSecret"]; ok {
+			bl.Location.AzureConfig.ClientSecret = strings.TrimSuffix(string(secretConfig.Data["clientSecret"]), "\n")
+		}
+		if _, ok := secretConfig.Data["federatedTokenFile"]; ok {
+			bl.Location.AzureConfig.FederatedTokenFile = strings.TrimSuffix(string(secretConfig.Data["federatedTokenFile"]), "\n")
+		}
+		if _, ok := secretConfig.Data["environment"]; ok {
+			bl.Location.AzureConfig.Environment = AzureEnvironmentType(strings.TrimSuffix(string(secretConfig.Data["environment"]), "\n"))
+		}
 	}
+
+	// Fall back to the pod-projected workload-identity env vars when the
+	// secret didn't provide explicit credentials, so that stork honors
+	// AKS workload-identity/pod-identity setups where account keys are
+	// disallowed by policy
+	if bl.Location.AzureConfig.TenantID == "" {
+		bl.Location.AzureConfig.TenantID = os.Getenv(azureTenantIDEnvVar)
+	}
+	if bl.Location.AzureConfig.ClientID == "" {
+		bl.Location.AzureConfig.ClientID = os.Getenv(azureClientIDEnvVar)
+	}
+	if bl.Location.AzureConfig.FederatedTokenFile == "" {
+		bl.Location.AzureConfig.FederatedTokenFile = os.Getenv(azureFederatedTokenFileEnvVar)
+	}
+
+	if bl.Location.AzureConfig.AuthMode == "" {
+		bl.Location.AzureConfig.AuthMode = defaultAzureAuthMode(bl.Location.AzureConfig)
+	}
+
 	return nil
 }
 
+// defaultAzureAuthMode infers the auth mode from the populated fields when
+// the caller didn't set one explicitly, preferring the most specific
+// credentials available
+func defaultAzureAuthMode(config *AzureConfig) AzureAuthModeType {
+	switch {
+	case config.FederatedTokenFile != "" && config.TenantID != "" && config.ClientID != "":
+		return AzureAuthModeWorkloadIdentity
+	case config.TenantID != "" && config.ClientID != "" && config.ClientSecret != "":
+		return AzureAuthModeServicePrincipal
+	case config.StorageAccountKey != "":
+		return AzureAuthModeSharedKey
+	default:
+		return AzureAuthModeManagedIdentity
+	}
+}
+
 func (bl *BackupLocation) getMergedGoogleConfig(client kubernetes.Interface) error {
 	if bl.Location.GoogleConfig == nil {
 		bl.Location.GoogleConfig = &GoogleConfig{}
@@ -162,6 +363,20 @@ func (bl *BackupLocation) getMergedGoogleConfig(client kubernetes.Interface) err
 		if _, ok := secretConfig.Data["accountKey"]; ok {
 			bl.Location.GoogleConfig.AccountKey = strings.TrimSuffix(string(secretConfig.Data["accountKey"]), "\n")
 		}
+		if _, ok := secretConfig.Data["authMode"]; ok {
+			bl.Location.GoogleConfig.AuthMode = GoogleAuthModeType(strings.TrimSuffix(string(secretConfig.Data["authMode"]), "\n"))
+		}
 	}
+
+	// Unlike RoleARN/WebIdentityTokenFile for AWS or TenantID/ClientID for
+	// Azure, GKE Workload Identity needs no pod-projected config of its own
+	// to detect, and GOOGLE_APPLICATION_CREDENTIALS conventionally points
+	// at a static service-account key file rather than signalling
+	// federation, so there's no env var to default from here. Callers that
+	// want workload identity must set AuthMode explicitly.
+	if bl.Location.GoogleConfig.AuthMode == "" {
+		bl.Location.GoogleConfig.AuthMode = GoogleAuthModeStatic
+	}
+
 	return nil
 }
@@ -0,0 +1,495 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials are the resolved, possibly short-lived, credentials needed to
+// talk to a BackupLocation's storage backend. Which fields are populated
+// depends on the BackupLocationType and AuthMode the IdentityProvider was
+// built for.
+type Credentials struct {
+	// AccessKeyID/SecretAccessKey/SessionToken are used for S3-compatible
+	// objectstores, either static or assumed via IRSA
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Token is an opaque bearer token, used for Azure AD and GCP access
+	// tokens obtained via workload identity
+	Token string
+	// Expiry is when Token/SessionToken stop being valid. Zero means the
+	// credentials don't expire (e.g. static secrets).
+	Expiry time.Time
+}
+
+// expired returns true if the credentials have an expiry and it has passed
+func (c *Credentials) expired() bool {
+	return !c.Expiry.IsZero() && time.Now().After(c.Expiry)
+}
+
+// IdentityProvider resolves the Credentials needed to access a
+// BackupLocation's storage backend, abstracting over static secrets and the
+// workload-identity schemes supported by each cloud. Implementations cache
+// the resolved credentials and refresh them once they expire.
+type IdentityProvider interface {
+	// Credentials returns valid credentials for the backend, resolving or
+	// refreshing them as needed
+	Credentials() (*Credentials, error)
+}
+
+// IdentityProviderFor returns the IdentityProvider that should be used to
+// authenticate against bl's storage backend, selected by BackupLocationType
+// and the config's AuthMode. bl.UpadteFromSecret should be called first so
+// that the config reflects the SecretConfig/workload-identity environment.
+func (bl *BackupLocation) IdentityProviderFor() (IdentityProvider, error) {
+	switch bl.Location.Type {
+	case BackupLocationS3:
+		if bl.Location.S3Config == nil {
+			return nil, fmt.Errorf("S3Config not provided for backupLocation")
+		}
+		if bl.Location.S3Config.AuthMode == S3AuthModeIRSA {
+			return &awsIRSAIdentityProvider{config: bl.Location.S3Config}, nil
+		}
+		return &staticIdentityProvider{
+			credentials: &Credentials{
+				AccessKeyID:     bl.Location.S3Config.AccessKeyID,
+				SecretAccessKey: bl.Location.S3Config.SecretAccessKey,
+			},
+		}, nil
+	case BackupLocationAzure:
+		if bl.Location.AzureConfig == nil {
+			return nil, fmt.Errorf("AzureConfig not provided for backupLocation")
+		}
+		switch bl.Location.AzureConfig.AuthMode {
+		case AzureAuthModeWorkloadIdentity:
+			return &azureWorkloadIdentityProvider{config: bl.Location.AzureConfig}, nil
+		case AzureAuthModeServicePrincipal:
+			return &azureServicePrincipalIdentityProvider{config: bl.Location.AzureConfig}, nil
+		case AzureAuthModeManagedIdentity:
+			return &azureManagedIdentityProvider{config: bl.Location.AzureConfig}, nil
+		}
+		return &staticIdentityProvider{
+			credentials: &Credentials{
+				AccessKeyID:     bl.Location.AzureConfig.StorageAccountName,
+				SecretAccessKey: bl.Location.AzureConfig.StorageAccountKey,
+			},
+		}, nil
+	case BackupLocationGoogle:
+		if bl.Location.GoogleConfig == nil {
+			return nil, fmt.Errorf("GoogleConfig not provided for backupLocation")
+		}
+		if bl.Location.GoogleConfig.AuthMode == GoogleAuthModeWorkloadIdentity {
+			return &gcpWorkloadIdentityProvider{config: bl.Location.GoogleConfig}, nil
+		}
+		return &staticIdentityProvider{
+			credentials: &Credentials{
+				SecretAccessKey: bl.Location.GoogleConfig.AccountKey,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("Invalid BackupLocation type %v", bl.Location.Type)
+	}
+}
+
+// staticIdentityProvider returns the same, never-expiring Credentials it
+// was constructed with. This backs the existing secret-based configs.
+type staticIdentityProvider struct {
+	credentials *Credentials
+}
+
+func (s *staticIdentityProvider) Credentials() (*Credentials, error) {
+	return s.credentials, nil
+}
+
+// awsIRSAIdentityProvider resolves credentials by exchanging the pod's
+// projected service account token for temporary credentials via
+// AssumeRoleWithWebIdentity, caching them until they expire.
+type awsIRSAIdentityProvider struct {
+	config *S3Config
+
+	mutex  sync.Mutex
+	cached *Credentials
+}
+
+func (a *awsIRSAIdentityProvider) Credentials() (*Credentials, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.cached != nil && !a.cached.expired() {
+		return a.cached, nil
+	}
+
+	token, err := ioutil.ReadFile(a.config.WebIdentityTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading web identity token for IRSA: %v", err)
+	}
+
+	creds, err := assumeRoleWithWebIdentity(a.config.RoleARN, strings.TrimSpace(string(token)))
+	if err != nil {
+		return nil, fmt.Errorf("error assuming role %v via IRSA: %v", a.config.RoleARN, err)
+	}
+	a.cached = creds
+	return a.cached, nil
+}
+
+// azureWorkloadIdentityProvider resolves credentials by exchanging the
+// pod's projected service account token for an Azure AD access token,
+// caching it until it expires.
+type azureWorkloadIdentityProvider struct {
+	config *AzureConfig
+
+	mutex  sync.Mutex
+	cached *Credentials
+}
+
+func (a *azureWorkloadIdentityProvider) Credentials() (*Credentials, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.cached != nil && !a.cached.expired() {
+		return a.cached, nil
+	}
+
+	token, err := ioutil.ReadFile(a.config.FederatedTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading federated token for workload identity: %v", err)
+	}
+
+	creds, err := exchangeAzureFederatedToken(a.config.TenantID, a.config.ClientID, strings.TrimSpace(string(token)))
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging federated token for Azure AD token: %v", err)
+	}
+	a.cached = creds
+	return a.cached, nil
+}
+
+// azureServicePrincipalIdentityProvider resolves credentials by exchanging
+// the configured Azure AD application's TenantID/ClientID/ClientSecret for
+// an access token via the OAuth2 client-credentials flow, caching it until
+// it expires.
+type azureServicePrincipalIdentityProvider struct {
+	config *AzureConfig
+
+	mutex  sync.Mutex
+	cached *Credentials
+}
+
+func (a *azureServicePrincipalIdentityProvider) Credentials() (*Credentials, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.cached != nil && !a.cached.expired() {
+		return a.cached, nil
+	}
+
+	creds, err := exchangeAzureClientSecret(a.config.TenantID, a.config.ClientID, a.config.ClientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging client secret for Azure AD token: %v", err)
+	}
+	a.cached = creds
+	return a.cached, nil
+}
+
+// azureManagedIdentityProvider resolves credentials from the Azure Instance
+// Metadata Service, as made available to VMs/pods with a managed identity
+// assigned, caching the access token until it expires.
+type azureManagedIdentityProvider struct {
+	config *AzureConfig
+
+	mutex  sync.Mutex
+	cached *Credentials
+}
+
+func (a *azureManagedIdentityProvider) Credentials() (*Credentials, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.cached != nil && !a.cached.expired() {
+		return a.cached, nil
+	}
+
+	creds, err := fetchAzureManagedIdentityToken(a.config.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching Azure managed identity token: %v", err)
+	}
+	a.cached = creds
+	return a.cached, nil
+}
+
+// gcpWorkloadIdentityProvider resolves credentials from the GCE metadata
+// server, as made available by GKE Workload Identity, caching the access
+// token until it expires.
+type gcpWorkloadIdentityProvider struct {
+	config *GoogleConfig
+
+	mutex  sync.Mutex
+	cached *Credentials
+}
+
+func (g *gcpWorkloadIdentityProvider) Credentials() (*Credentials, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.cached != nil && !g.cached.expired() {
+		return g.cached, nil
+	}
+
+	creds, err := fetchGCPWorkloadIdentityToken()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching GCP workload identity token: %v", err)
+	}
+	g.cached = creds
+	return g.cached, nil
+}
+
+const (
+	awsSTSEndpoint             = "https://sts.amazonaws.com/"
+	azureADTokenEndpointFormat = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	azureStorageScope          = "https://storage.azure.com/.default"
+	azureIMDSTokenEndpoint     = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureIMDSTokenAPIVersion   = "2018-02-01"
+	gcpMetadataTokenEndpoint   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// identityHTTPTimeout bounds every outbound credential-exchange call
+	// (STS, Azure AD, Azure IMDS, GCE metadata) so a slow or unreachable
+	// endpoint fails fast instead of hanging the caller indefinitely
+	identityHTTPTimeout = 10 * time.Second
+)
+
+// identityHTTPClient is used for all outbound credential-exchange calls
+var identityHTTPClient = &http.Client{
+	Timeout: identityHTTPTimeout,
+}
+
+// assumeRoleWithWebIdentityResponse is the subset of the AWS STS
+// AssumeRoleWithWebIdentity XML response that's needed to build Credentials
+type assumeRoleWithWebIdentityResponse struct {
+	XMLName xml.Name `xml:"AssumeRoleWithWebIdentityResponse"`
+	Result  struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+			Expiration      string `xml:"Expiration"`
+		} `xml:"Credentials"`
+	} `xml:"AssumeRoleWithWebIdentityResult"`
+}
+
+// assumeRoleWithWebIdentity exchanges a projected service account token for
+// temporary AWS credentials by calling AWS STS directly, so that IRSA works
+// without vendoring the full AWS SDK
+func assumeRoleWithWebIdentity(roleARN, webIdentityToken string) (*Credentials, error) {
+	values := url.Values{}
+	values.Set("Action", "AssumeRoleWithWebIdentity")
+	values.Set("Version", "2011-06-15")
+	values.Set("RoleArn", roleARN)
+	values.Set("RoleSessionName", "stork")
+	values.Set("WebIdentityToken", webIdentityToken)
+
+	resp, err := identityHTTPClient.PostForm(awsSTSEndpoint, values)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v from AWS STS", resp.StatusCode)
+	}
+
+	var stsResp assumeRoleWithWebIdentityResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&stsResp); err != nil {
+		return nil, err
+	}
+
+	expiry, err := time.Parse(time.RFC3339, stsResp.Result.Credentials.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing credential expiration: %v", err)
+	}
+
+	return &Credentials{
+		AccessKeyID:     stsResp.Result.Credentials.AccessKeyID,
+		SecretAccessKey: stsResp.Result.Credentials.SecretAccessKey,
+		SessionToken:    stsResp.Result.Credentials.SessionToken,
+		Expiry:          expiry,
+	}, nil
+}
+
+// azureADTokenResponse is the token response returned by the Azure AD v2.0
+// token endpoint. Unlike the v1.0 endpoint (and Azure IMDS), v2.0 returns
+// expires_in as a JSON number, not a string.
+type azureADTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// exchangeAzureFederatedToken exchanges a projected service account token
+// for an Azure AD access token scoped to Azure Storage, following the
+// client-credentials federated-identity flow used by AKS workload identity
+func exchangeAzureFederatedToken(tenantID, clientID, federatedToken string) (*Credentials, error) {
+	return exchangeAzureFederatedTokenAt(fmt.Sprintf(azureADTokenEndpointFormat, tenantID), clientID, federatedToken)
+}
+
+// exchangeAzureFederatedTokenAt is exchangeAzureFederatedToken against an
+// explicit token endpoint, so tests can point it at an httptest.Server
+// instead of Azure AD
+func exchangeAzureFederatedTokenAt(tokenEndpoint, clientID, federatedToken string) (*Credentials, error) {
+	values := url.Values{}
+	values.Set("client_id", clientID)
+	values.Set("grant_type", "client_credentials")
+	values.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	values.Set("client_assertion", federatedToken)
+	values.Set("scope", azureStorageScope)
+
+	resp, err := identityHTTPClient.PostForm(tokenEndpoint, values)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v from Azure AD token endpoint", resp.StatusCode)
+	}
+
+	var tokenResp azureADTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, err
+	}
+
+	return &Credentials{
+		Token:  tokenResp.AccessToken,
+		Expiry: time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// exchangeAzureClientSecret exchanges an Azure AD application's tenant ID,
+// client ID and client secret for an access token scoped to Azure Storage,
+// via the OAuth2 client-credentials flow used for service-principal
+// authentication
+func exchangeAzureClientSecret(tenantID, clientID, clientSecret string) (*Credentials, error) {
+	return exchangeAzureClientSecretAt(fmt.Sprintf(azureADTokenEndpointFormat, tenantID), clientID, clientSecret)
+}
+
+// exchangeAzureClientSecretAt is exchangeAzureClientSecret against an
+// explicit token endpoint, so tests can point it at an httptest.Server
+// instead of Azure AD
+func exchangeAzureClientSecretAt(tokenEndpoint, clientID, clientSecret string) (*Credentials, error) {
+	values := url.Values{}
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("grant_type", "client_credentials")
+	values.Set("scope", azureStorageScope)
+
+	resp, err := identityHTTPClient.PostForm(tokenEndpoint, values)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v from Azure AD token endpoint", resp.StatusCode)
+	}
+
+	var tokenResp azureADTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, err
+	}
+
+	return &Credentials{
+		Token:  tokenResp.AccessToken,
+		Expiry: time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// azureIMDSTokenResponse is the token response returned by the Azure
+// Instance Metadata Service. Unlike the Azure AD v2.0 token endpoint, IMDS
+// returns expires_in as a JSON string.
+type azureIMDSTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   string `json:"expires_in"`
+}
+
+// fetchAzureManagedIdentityToken fetches an Azure Storage access token for
+// the VM/pod's assigned managed identity from the Azure Instance Metadata
+// Service. clientID selects a user-assigned identity; it's omitted from the
+// request when empty, which resolves the system-assigned identity instead.
+func fetchAzureManagedIdentityToken(clientID string) (*Credentials, error) {
+	query := url.Values{}
+	query.Set("api-version", azureIMDSTokenAPIVersion)
+	query.Set("resource", azureStorageScope)
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, azureIMDSTokenEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := identityHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v from Azure Instance Metadata Service", resp.StatusCode)
+	}
+
+	var tokenResp azureIMDSTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, err
+	}
+
+	expiresIn, err := strconv.Atoi(tokenResp.ExpiresIn)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token expiry: %v", err)
+	}
+
+	return &Credentials{
+		Token:  tokenResp.AccessToken,
+		Expiry: time.Now().Add(time.Duration(expiresIn) * time.Second),
+	}, nil
+}
+
+// gcpMetadataTokenResponse is the token response returned by the GCE
+// metadata server's service-account token endpoint
+type gcpMetadataTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// fetchGCPWorkloadIdentityToken fetches an access token for the pod's bound
+// Kubernetes service account from the GCE metadata server, as made
+// available by GKE Workload Identity
+func fetchGCPWorkloadIdentityToken() (*Credentials, error) {
+	req, err := http.NewRequest(http.MethodGet, gcpMetadataTokenEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := identityHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v from GCP metadata server", resp.StatusCode)
+	}
+
+	var tokenResp gcpMetadataTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, err
+	}
+
+	return &Credentials{
+		Token:  tokenResp.AccessToken,
+		Expiry: time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
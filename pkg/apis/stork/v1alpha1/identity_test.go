@@ -0,0 +1,81 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// sampleAzureADV2TokenResponse is a representative response from the Azure
+// AD v2.0 token endpoint, where expires_in is a JSON number rather than a
+// string.
+const sampleAzureADV2TokenResponse = `{
+	"token_type": "Bearer",
+	"expires_in": 3599,
+	"ext_expires_in": 3599,
+	"access_token": "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.sample.signature"
+}`
+
+func TestAzureADTokenResponseDecodesV2ExpiresInAsNumber(t *testing.T) {
+	var tokenResp azureADTokenResponse
+	if err := json.Unmarshal([]byte(sampleAzureADV2TokenResponse), &tokenResp); err != nil {
+		t.Fatalf("failed to decode sample v2.0 token response: %v", err)
+	}
+
+	if tokenResp.ExpiresIn != 3599 {
+		t.Fatalf("expected ExpiresIn 3599, got %v", tokenResp.ExpiresIn)
+	}
+	if tokenResp.AccessToken == "" {
+		t.Fatal("expected a non-empty access token")
+	}
+}
+
+func TestExchangeAzureFederatedToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %v", err)
+		}
+		if r.Form.Get("client_assertion") != "federated-token" {
+			t.Fatalf("expected client_assertion %q, got %q", "federated-token", r.Form.Get("client_assertion"))
+		}
+		w.Write([]byte(sampleAzureADV2TokenResponse))
+	}))
+	defer server.Close()
+
+	creds, err := exchangeAzureFederatedTokenAt(server.URL, "client", "federated-token")
+	if err != nil {
+		t.Fatalf("exchangeAzureFederatedTokenAt returned error: %v", err)
+	}
+	if creds.Token == "" {
+		t.Fatal("expected a non-empty token")
+	}
+	if creds.Expiry.Before(time.Now()) {
+		t.Fatal("expected an expiry in the future")
+	}
+}
+
+func TestExchangeAzureClientSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %v", err)
+		}
+		if r.Form.Get("client_secret") != "shh" {
+			t.Fatalf("expected client_secret %q, got %q", "shh", r.Form.Get("client_secret"))
+		}
+		w.Write([]byte(sampleAzureADV2TokenResponse))
+	}))
+	defer server.Close()
+
+	creds, err := exchangeAzureClientSecretAt(server.URL, "client", "shh")
+	if err != nil {
+		t.Fatalf("exchangeAzureClientSecretAt returned error: %v", err)
+	}
+	if creds.Token == "" {
+		t.Fatal("expected a non-empty token")
+	}
+	if creds.Expiry.Before(time.Now()) {
+		t.Fatal("expected an expiry in the future")
+	}
+}
@@ -0,0 +1,75 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ResourceTransformationResourceName is name for "resourcetransformation" resource
+	ResourceTransformationResourceName = "resourcetransformation"
+	// ResourceTransformationResourcePlural is plural for "resourcetransformation" resource
+	ResourceTransformationResourcePlural = "resourcetransformations"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ResourceTransformation is a cluster-scoped resource that lets a user
+// strip or rewrite fields on resources of a given kind as they're collected
+// for migration/backup, without having to patch stork itself
+type ResourceTransformation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ResourceTransformationSpec `json:"spec"`
+}
+
+// ResourceTransformationSpec defines the target kind and the operations to
+// apply to objects of that kind
+type ResourceTransformationSpec struct {
+	// Group is the API group of the resources this transformation applies to
+	Group string `json:"group"`
+	// Version is the API version of the resources this transformation applies to
+	Version string `json:"version"`
+	// Kind is the kind of the resources this transformation applies to
+	Kind string `json:"kind"`
+	// Operations are applied in order to every matching object
+	Operations []ResourceTransformationOperation `json:"operations"`
+}
+
+// ResourceTransformationOperation is a single JSONPath-addressed edit
+// applied to a collected resource
+type ResourceTransformationOperation struct {
+	// Type is the kind of edit to perform
+	Type ResourceTransformationOperationType `json:"type"`
+	// Path is the JSONPath of the field the operation applies to, e.g.
+	// "spec.ports[0].nodePort"
+	Path string `json:"path"`
+	// Value is used by the Replace and Add operations as the new value
+	// for Path
+	Value string `json:"value,omitempty"`
+}
+
+// ResourceTransformationOperationType is the kind of edit a
+// ResourceTransformationOperation performs
+type ResourceTransformationOperationType string
+
+const (
+	// ResourceTransformationOperationDelete removes the field at Path
+	ResourceTransformationOperationDelete ResourceTransformationOperationType = "delete"
+	// ResourceTransformationOperationReplace overwrites the field at Path with Value
+	ResourceTransformationOperationReplace ResourceTransformationOperationType = "replace"
+	// ResourceTransformationOperationAdd sets the field at Path to Value,
+	// creating it if it doesn't already exist
+	ResourceTransformationOperationAdd ResourceTransformationOperationType = "add"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ResourceTransformationList is a list of ResourceTransformations
+type ResourceTransformationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ResourceTransformation `json:"items"`
+}
@@ -22,9 +22,14 @@ import (
 
 // ResourceCollector is used to collect and process unstructured objects in namespaces and using label selectors
 type ResourceCollector struct {
-	Driver           volume.Driver
-	discoveryHelper  discovery.Helper
-	dynamicInterface dynamic.Interface
+	Driver             volume.Driver
+	discoveryHelper    discovery.Helper
+	dynamicInterface   dynamic.Interface
+	aeClient           apiextensionsclient.Interface
+	transformers       map[schema.GroupVersionKind][]ResourceTransformer
+	resourceTypePolicy ResourceTypePolicy
+	objectFilters      []ObjectFilter
+	crdGroupKinds      map[schema.GroupKind]bool
 }
 
 // Init initializes the resource collector
@@ -38,6 +43,7 @@ func (r *ResourceCollector) Init() error {
 	if err != nil {
 		return fmt.Errorf("Error getting apiextention client, %v", err)
 	}
+	r.aeClient = aeclient
 
 	discoveryClient := aeclient.Discovery()
 	r.discoveryHelper, err = discovery.NewHelper(discoveryClient, logrus.New())
@@ -52,32 +58,11 @@ func (r *ResourceCollector) Init() error {
 	if err != nil {
 		return err
 	}
-	return nil
-}
 
-func resourceToBeCollected(resource metav1.APIResource) bool {
-	// Deployment is present in "apps" and "extensions" group, so ignore
-	// "extensions"
-	if resource.Group == "extensions" && resource.Kind == "Deployment" {
-		return false
-	}
+	r.registerBuiltinTransformers()
+	r.loadResourceTransformations(config)
 
-	switch resource.Kind {
-	case "PersistentVolumeClaim",
-		"PersistentVolume",
-		"Deployment",
-		"StatefulSet",
-		"ConfigMap",
-		"Service",
-		"Secret",
-		"DaemonSet",
-		"ServiceAccount",
-		"ClusterRole",
-		"ClusterRoleBinding":
-		return true
-	default:
-		return false
-	}
+	return nil
 }
 
 // GetResources gets all the resources in the given list of namespaces which match the labelSelectors
@@ -86,6 +71,11 @@ func (r *ResourceCollector) GetResources(namespaces []string, labelSelectors map
 	if err != nil {
 		return nil, err
 	}
+	if r.resourceTypePolicy.IncludeAllCRDs {
+		if err := r.refreshCRDGroupKinds(); err != nil {
+			return nil, err
+		}
+	}
 	allObjects := make([]runtime.Unstructured, 0)
 
 	for _, group := range r.discoveryHelper.Resources() {
@@ -100,7 +90,7 @@ func (r *ResourceCollector) GetResources(namespaces []string, labelSelectors map
 		// Map to prevent collection of duplicate objects
 		resourceMap := make(map[types.UID]bool)
 		for _, resource := range group.APIResources {
-			if !resourceToBeCollected(resource) {
+			if !r.resourceToBeCollected(resource) {
 				continue
 			}
 
@@ -296,6 +286,16 @@ func (r *ResourceCollector) objectToBeCollected(
 		}
 	}
 
+	for _, filter := range r.objectFilters {
+		collect, err := filter(metadata, objectType.GetKind(), namespace)
+		if err != nil {
+			return false, err
+		}
+		if !collect {
+			return false, nil
+		}
+	}
+
 	return true, nil
 }
 
@@ -345,17 +345,11 @@ func (r *ResourceCollector) prepareResources(
 			return err
 		}
 
-		switch o.GetObjectKind().GroupVersionKind().Kind {
-		case "PersistentVolume":
-			updatedObject, err := r.preparePVResource(o)
-			if err != nil {
-				return fmt.Errorf("Error preparing PV resource %v: %v", metadata.GetName(), err)
-			}
-			o = updatedObject
-		case "Service":
-			updatedObject, err := r.prepareServiceResource(o)
+		gvk := o.GetObjectKind().GroupVersionKind()
+		for _, transformer := range r.transformers[gvk] {
+			updatedObject, err := transformer(o)
 			if err != nil {
-				return fmt.Errorf("Error preparing Service resource %v/%v: %v", metadata.GetNamespace(), metadata.GetName(), err)
+				return fmt.Errorf("Error transforming resource %v/%v: %v", metadata.GetNamespace(), metadata.GetName(), err)
 			}
 			o = updatedObject
 		}
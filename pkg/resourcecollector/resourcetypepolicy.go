@@ -0,0 +1,130 @@
+package resourcecollector
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// defaultIncludedGroupKinds is the whitelist resourceToBeCollected used
+// before ResourceTypePolicy existed. It's kept as the default so existing
+// deployments don't silently start collecting more than before.
+var defaultIncludedGroupKinds = map[schema.GroupKind]bool{
+	{Kind: "PersistentVolumeClaim"}: true,
+	{Kind: "PersistentVolume"}:      true,
+	{Kind: "Deployment"}:            true,
+	{Kind: "StatefulSet"}:           true,
+	{Kind: "ConfigMap"}:             true,
+	{Kind: "Service"}:               true,
+	{Kind: "Secret"}:                true,
+	{Kind: "DaemonSet"}:             true,
+	{Kind: "ServiceAccount"}:        true,
+	{Kind: "ClusterRole"}:           true,
+	{Kind: "ClusterRoleBinding"}:    true,
+}
+
+// ResourceTypePolicy controls which resource kinds GetResources collects,
+// replacing the old fixed eleven-kind whitelist. It's applied on top of
+// the default whitelist: Include adds kinds (such as CronJobs, Ingresses,
+// NetworkPolicies, HPAs, PodDisruptionBudgets, Roles/RoleBindings or CRDs
+// like Prometheus/Istio/cert-manager objects) and Exclude removes them,
+// whichever group they're looked up in afterwards.
+type ResourceTypePolicy struct {
+	// IncludeGroupKinds are collected in addition to the default whitelist
+	IncludeGroupKinds []schema.GroupKind
+	// ExcludeGroupKinds are never collected, even if in the default
+	// whitelist or IncludeGroupKinds
+	ExcludeGroupKinds []schema.GroupKind
+	// IncludeAllCRDs collects every namespaced custom resource registered
+	// in the cluster, discovered via the apiextensions client, unless its
+	// GroupKind is in ExcludeGroupKinds
+	IncludeAllCRDs bool
+}
+
+// SetResourceTypePolicy replaces the ResourceCollector's ResourceTypePolicy.
+// If this is never called, the zero-value policy applies (the default
+// whitelist with no additions).
+func (r *ResourceCollector) SetResourceTypePolicy(policy ResourceTypePolicy) {
+	r.resourceTypePolicy = policy
+}
+
+// RegisterObjectFilter appends a per-kind filter run after the built-in
+// rules in objectToBeCollected, so callers can add rules like "skip Jobs
+// owned by CronJobs" without editing stork. Filters are not run for
+// PersistentVolumeClaim, PersistentVolume, ClusterRole and
+// ClusterRoleBinding objects, whose built-in rules decide on their own.
+func (r *ResourceCollector) RegisterObjectFilter(filter ObjectFilter) {
+	r.objectFilters = append(r.objectFilters, filter)
+}
+
+// resourceToBeCollected returns whether resources of the given
+// metav1.APIResource kind should be collected, based on the
+// ResourceCollector's ResourceTypePolicy
+func (r *ResourceCollector) resourceToBeCollected(resource metav1.APIResource) bool {
+	// Deployment is present in "apps" and "extensions" group, so ignore
+	// "extensions"
+	if resource.Group == "extensions" && resource.Kind == "Deployment" {
+		return false
+	}
+
+	gk := schema.GroupKind{Group: resource.Group, Kind: resource.Kind}
+	if groupKindMatches(r.resourceTypePolicy.ExcludeGroupKinds, gk) {
+		return false
+	}
+
+	if defaultIncludedGroupKinds[schema.GroupKind{Kind: resource.Kind}] {
+		return true
+	}
+	if groupKindMatches(r.resourceTypePolicy.IncludeGroupKinds, gk) {
+		return true
+	}
+	if r.resourceTypePolicy.IncludeAllCRDs && resource.Namespaced && r.crdGroupKinds[gk] {
+		return true
+	}
+	return false
+}
+
+func groupKindMatches(groupKinds []schema.GroupKind, gk schema.GroupKind) bool {
+	for _, candidate := range groupKinds {
+		if candidate == gk {
+			return true
+		}
+		// A GroupKind with no Group set matches the kind in any group,
+		// mirroring how the default whitelist ignores API groups
+		if candidate.Group == "" && candidate.Kind == gk.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshCRDGroupKinds (re-)populates the set of namespaced custom
+// resource GroupKinds registered in the cluster, used by
+// ResourceTypePolicy.IncludeAllCRDs
+func (r *ResourceCollector) refreshCRDGroupKinds() error {
+	crds, err := r.aeClient.ApiextensionsV1beta1().CustomResourceDefinitions().List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	crdGroupKinds := make(map[schema.GroupKind]bool)
+	for _, crd := range crds.Items {
+		if crd.Spec.Scope != apiextensionsv1beta1NamespaceScoped {
+			continue
+		}
+		crdGroupKinds[schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}] = true
+	}
+	r.crdGroupKinds = crdGroupKinds
+	return nil
+}
+
+// apiextensionsv1beta1NamespaceScoped mirrors
+// apiextensionsv1beta1.NamespaceScoped without importing the package just
+// for this one constant comparison
+const apiextensionsv1beta1NamespaceScoped = "Namespaced"
+
+// ObjectFilter is a per-kind extension point run after the built-in rules
+// in objectToBeCollected, for every kind except PersistentVolumeClaim,
+// PersistentVolume, ClusterRole and ClusterRoleBinding. Returning false
+// excludes the object from collection; the error return lets a filter
+// abort collection entirely.
+type ObjectFilter func(metadata metav1.Object, kind string, namespace string) (bool, error)
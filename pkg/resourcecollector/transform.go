@@ -0,0 +1,187 @@
+package resourcecollector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	stork "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// ResourceTransformer transforms a collected object, for example to strip
+// cluster-specific fields before it's applied elsewhere. Transformers are
+// run in registration order for the object's GroupVersionKind.
+type ResourceTransformer func(runtime.Unstructured) (runtime.Unstructured, error)
+
+// RegisterTransformer adds transformer to the end of the chain run for
+// objects of the given GroupVersionKind. Built-in transformers for
+// PersistentVolume and Service are registered by Init; callers can append
+// their own to run additional cleanup without patching stork.
+func (r *ResourceCollector) RegisterTransformer(gvk schema.GroupVersionKind, transformer ResourceTransformer) {
+	if r.transformers == nil {
+		r.transformers = make(map[schema.GroupVersionKind][]ResourceTransformer)
+	}
+	r.transformers[gvk] = append(r.transformers[gvk], transformer)
+}
+
+// registerBuiltinTransformers installs the transformers that used to be
+// hard-coded in prepareResources
+func (r *ResourceCollector) registerBuiltinTransformers() {
+	r.RegisterTransformer(schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolume"}, r.preparePVResource)
+	r.RegisterTransformer(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, r.prepareServiceResource)
+}
+
+// loadResourceTransformations compiles the cluster's ResourceTransformation
+// CRs into ResourceTransformer functions and registers them, so operators
+// can strip cluster-specific fields (nodePorts, loadBalancerIPs,
+// finalizers, owner refs) during migration/backup without patching stork.
+// ResourceTransformation is an optional CRD: a cluster that hasn't applied
+// it yet shouldn't fail Init and take down the rest of the ResourceCollector
+// with it, so errors here are logged and swallowed rather than returned.
+func (r *ResourceCollector) loadResourceTransformations(config *rest.Config) {
+	storkClient, err := storkclientset.NewForConfig(config)
+	if err != nil {
+		logrus.Errorf("Error getting stork client, resource transformations will not be applied: %v", err)
+		return
+	}
+
+	transformations, err := storkClient.StorkV1alpha1().ResourceTransformations().List(metav1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("Error listing resource transformations, continuing without them (the ResourceTransformation CRD may not be registered on this cluster): %v", err)
+		return
+	}
+
+	for _, transformation := range transformations.Items {
+		gvk := schema.GroupVersionKind{
+			Group:   transformation.Spec.Group,
+			Version: transformation.Spec.Version,
+			Kind:    transformation.Spec.Kind,
+		}
+		r.RegisterTransformer(gvk, compileResourceTransformation(transformation))
+	}
+}
+
+// compileResourceTransformation turns a ResourceTransformation CR's
+// operations into a single ResourceTransformer
+func compileResourceTransformation(transformation stork.ResourceTransformation) ResourceTransformer {
+	return func(object runtime.Unstructured) (runtime.Unstructured, error) {
+		content := object.UnstructuredContent()
+		for _, op := range transformation.Spec.Operations {
+			switch op.Type {
+			case stork.ResourceTransformationOperationDelete:
+				if err := deleteJSONPath(content, op.Path); err != nil {
+					return nil, fmt.Errorf("error deleting %v: %v", op.Path, err)
+				}
+			case stork.ResourceTransformationOperationReplace, stork.ResourceTransformationOperationAdd:
+				if err := setJSONPath(content, op.Path, op.Value); err != nil {
+					return nil, fmt.Errorf("error setting %v: %v", op.Path, err)
+				}
+			default:
+				return nil, fmt.Errorf("unknown resource transformation operation %v", op.Type)
+			}
+		}
+		return object, nil
+	}
+}
+
+// jsonPathSegments splits a simple JSONPath like "spec.ports[0].nodePort"
+// into ["spec", "ports", "0", "nodePort"]
+func jsonPathSegments(path string) []string {
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	return strings.Split(path, ".")
+}
+
+// deleteJSONPath removes the field at path from content, leaving content
+// unchanged if any intermediate segment doesn't exist
+func deleteJSONPath(content map[string]interface{}, path string) error {
+	segments := jsonPathSegments(path)
+	if len(segments) == 0 {
+		return fmt.Errorf("empty path")
+	}
+	parent, key, ok, err := navigateToParent(content, segments)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	if m, ok := parent.(map[string]interface{}); ok {
+		delete(m, key)
+	}
+	return nil
+}
+
+// setJSONPath sets the field at path to value, creating intermediate maps
+// as needed. Like navigateToParent, a numeric segment such as the "0" in
+// "spec.ports[0].nodePort" indexes into an existing array rather than being
+// treated as a map key, so array elements are addressed instead of
+// clobbered.
+func setJSONPath(content map[string]interface{}, path string, value string) error {
+	segments := jsonPathSegments(path)
+	if len(segments) == 0 {
+		return fmt.Errorf("empty path")
+	}
+	var current interface{} = content
+	for _, segment := range segments[:len(segments)-1] {
+		if index, err := strconv.Atoi(segment); err == nil {
+			slice, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(slice) {
+				return fmt.Errorf("no element at index %v in path %v", segment, path)
+			}
+			current = slice[index]
+			continue
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot traverse into %v in path %v", segment, path)
+		}
+		next, ok := m[segment]
+		if !ok {
+			next = make(map[string]interface{})
+			m[segment] = next
+		}
+		current = next
+	}
+	m, ok := current.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("cannot set %v in path %v", segments[len(segments)-1], path)
+	}
+	m[segments[len(segments)-1]] = value
+	return nil
+}
+
+// navigateToParent walks content following segments[:len-1] and returns the
+// container holding the final segment, the final segment's key, and
+// whether the full path could be resolved. A numeric segment indexes into
+// the array resolved by the previous segment rather than being looked up
+// as a map key.
+func navigateToParent(content map[string]interface{}, segments []string) (interface{}, string, bool, error) {
+	var current interface{} = content
+	for _, segment := range segments[:len(segments)-1] {
+		if index, err := strconv.Atoi(segment); err == nil {
+			slice, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(slice) {
+				return nil, "", false, nil
+			}
+			current = slice[index]
+			continue
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, "", false, nil
+		}
+		next, ok := m[segment]
+		if !ok {
+			return nil, "", false, nil
+		}
+		current = next
+	}
+	return current, segments[len(segments)-1], true, nil
+}
@@ -0,0 +1,104 @@
+package resourcecollector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetJSONPathArrayElement(t *testing.T) {
+	content := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": "80", "nodePort": "30000"},
+			},
+		},
+	}
+
+	if err := setJSONPath(content, "spec.ports[0].nodePort", "30080"); err != nil {
+		t.Fatalf("setJSONPath returned error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": "80", "nodePort": "30080"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(content, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, content)
+	}
+}
+
+func TestSetJSONPathCreatesIntermediateMaps(t *testing.T) {
+	content := map[string]interface{}{}
+
+	if err := setJSONPath(content, "metadata.labels.env", "staging"); err != nil {
+		t.Fatalf("setJSONPath returned error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				"env": "staging",
+			},
+		},
+	}
+	if !reflect.DeepEqual(content, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, content)
+	}
+}
+
+func TestSetJSONPathMissingArrayIndexErrors(t *testing.T) {
+	content := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{},
+		},
+	}
+
+	if err := setJSONPath(content, "spec.ports[0].nodePort", "30080"); err == nil {
+		t.Fatal("expected an error indexing past the end of an empty array")
+	}
+}
+
+func TestDeleteJSONPathArrayElement(t *testing.T) {
+	content := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": "80", "nodePort": "30000"},
+			},
+		},
+	}
+
+	if err := deleteJSONPath(content, "spec.ports[0].nodePort"); err != nil {
+		t.Fatalf("deleteJSONPath returned error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": "80"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(content, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, content)
+	}
+}
+
+func TestDeleteJSONPathMissingSegmentIsNoop(t *testing.T) {
+	content := map[string]interface{}{
+		"spec": map[string]interface{}{},
+	}
+
+	if err := deleteJSONPath(content, "spec.ports[0].nodePort"); err != nil {
+		t.Fatalf("deleteJSONPath returned error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"spec": map[string]interface{}{},
+	}
+	if !reflect.DeepEqual(content, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, content)
+	}
+}